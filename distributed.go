@@ -0,0 +1,426 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// Distributed search protocol: a simple length-prefixed (4-byte big-endian
+// length, then JSON) message stream over TCP. A coordinator hands out
+// disjoint 64-bit nonce ranges to registered workers and collects the
+// winning identity; this lets a search scale across many machines instead of
+// one box's CPU cores. A worker that exhausts its range without a match
+// sends a fresh "register" over the same connection and is handed the next
+// disjoint range, so the connection stays open for as many ranges as the
+// search needs.
+//
+// Message types:
+//
+//	register  worker -> coordinator: {token, capability} (sent once to open the
+//	          connection, then again after each exhausted range)
+//	assign    coordinator -> worker: {range_start, range_size, prefix, postfix, patterns}
+//	heartbeat worker -> coordinator: {attempts}
+//	found     worker -> coordinator: {identity, address}
+//	error     coordinator -> worker: {error}
+const (
+	msgRegister  = "register"
+	msgAssign    = "assign"
+	msgHeartbeat = "heartbeat"
+	msgFound     = "found"
+	msgError     = "error"
+)
+
+const maxWireMessageSize = 1 << 20 // 1 MiB, generous for a JSON control message
+
+type wireMessage struct {
+	Type       string   `json:"type"`
+	Token      string   `json:"token,omitempty"`
+	Capability string   `json:"capability,omitempty"`
+	RangeStart uint64   `json:"range_start,omitempty"`
+	RangeSize  uint64   `json:"range_size,omitempty"`
+	Attempts   uint64   `json:"attempts,omitempty"`
+	Prefix     string   `json:"prefix,omitempty"`
+	Postfix    string   `json:"postfix,omitempty"`
+	Patterns   []string `json:"patterns,omitempty"`
+	Identity   []byte   `json:"identity,omitempty"` // 64-byte private blob
+	Address    string   `json:"address,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+func writeMessage(w io.Writer, msg *wireMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(payload)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(payload)
+	return err
+}
+
+func readMessage(r io.Reader) (*wireMessage, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	if n > maxWireMessageSize {
+		return nil, fmt.Errorf("message too large: %d bytes", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	var msg wireMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// runCoordinator implements the "coordinator" subcommand: accept worker
+// connections, hand out disjoint nonce ranges, and collect the winner.
+func runCoordinator(args []string) {
+	fs := flag.NewFlagSet("coordinator", flag.ExitOnError)
+	listenAddr := fs.String("listen", ":7337", "Address to listen on for workers")
+	token := fs.String("token", "", "Shared authentication token workers must present")
+	out := fs.String("out", "identity", "Output path for the winning identity")
+	prefixFlag := fs.String("prefix", "", "Desired hex prefix (1-32 chars)")
+	postfixFlag := fs.String("postfix", "", "Desired hex postfix/suffix (1-32 chars)")
+	var patternsFlag patternList
+	fs.Var(&patternsFlag, "pattern", "Additional vanity target: \"prefix:postfix\" or \"re:<regex>\" against the hex address; repeatable")
+	rangeSize := fs.Uint64("range-size", 1<<32, "Size of each nonce range assigned to a worker")
+	fs.Parse(args)
+
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "Error: --token is required")
+		os.Exit(1)
+	}
+	*prefixFlag = strings.ToLower(*prefixFlag)
+	*postfixFlag = strings.ToLower(*postfixFlag)
+	if !isHex(*prefixFlag) || !isHex(*postfixFlag) {
+		fmt.Fprintln(os.Stderr, "Error: --prefix/--postfix must contain only hex characters [0-9a-fA-F]")
+		os.Exit(1)
+	}
+
+	// The coordinator holds the pattern set; build it once up front so a
+	// typo in --prefix/--postfix/--pattern is reported immediately instead
+	// of after workers have already registered.
+	patternSet := []string(patternsFlag)
+	matchers, err := buildMatchers(*prefixFlag, *postfixFlag, patternSet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ln, err := net.Listen("tcp", *listenAddr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer ln.Close()
+	fmt.Printf("Coordinator listening on %s for targets:\n", *listenAddr)
+	for _, m := range matchers {
+		fmt.Printf("  %s\n", m.Label())
+	}
+
+	var nextRangeStart uint64
+	var stopped uint32
+	resultCh := make(chan *wireMessage, 1)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if atomic.LoadUint32(&stopped) == 1 {
+					return
+				}
+				fmt.Fprintf(os.Stderr, "accept error: %v\n", err)
+				continue
+			}
+			go handleWorkerConn(conn, *token, *prefixFlag, *postfixFlag, patternSet, &nextRangeStart, *rangeSize, resultCh, &stopped)
+		}
+	}()
+
+	// Report the cluster's aggregated attempt rate the same way the
+	// single-box search does, so the hash-rate aggregation this protocol
+	// collects via heartbeats is actually visible.
+	go monitorProgress()
+
+	result := <-resultCh
+	atomic.StoreUint32(&found, 1)
+	atomic.StoreUint32(&stopped, 1)
+	ln.Close()
+
+	identity, err := identityFromPrivateBlob(result.Identity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n✓ Found matching address: %s\n", result.Address)
+	if err := saveIdentity(identity, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving identity: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("  Saved to: %s\n", *out)
+}
+
+// handleWorkerConn serves one worker connection for its whole lifetime: it
+// authenticates the initial registration, then keeps handing out fresh
+// disjoint ranges (drawn from the shared nextRangeStart counter) each time
+// the worker exhausts one and re-registers, until the worker reports a find
+// or the overall search stops.
+func handleWorkerConn(conn net.Conn, token, prefix, postfix string, patterns []string, nextRangeStart *uint64, rangeSize uint64, resultCh chan<- *wireMessage, stopped *uint32) {
+	defer conn.Close()
+
+	reg, err := readMessage(conn)
+	if err != nil || reg.Type != msgRegister || reg.Token != token {
+		writeMessage(conn, &wireMessage{Type: msgError, Error: "unauthorized"})
+		return
+	}
+
+	for {
+		if atomic.LoadUint32(stopped) == 1 {
+			return
+		}
+
+		rangeStart := atomic.AddUint64(nextRangeStart, rangeSize) - rangeSize
+		fmt.Printf("worker registered: capability=%q addr=%s range=[%d,%d)\n", reg.Capability, conn.RemoteAddr(), rangeStart, rangeStart+rangeSize)
+
+		if err := writeMessage(conn, &wireMessage{Type: msgAssign, RangeStart: rangeStart, RangeSize: rangeSize, Prefix: prefix, Postfix: postfix, Patterns: patterns}); err != nil {
+			return
+		}
+
+		msg, err := readNextRegisterOrResult(conn, stopped)
+		if err != nil {
+			return
+		}
+		switch msg.Type {
+		case msgFound:
+			if atomic.CompareAndSwapUint32(stopped, 0, 1) {
+				select {
+				case resultCh <- msg:
+				default:
+				}
+			}
+			return
+		case msgRegister:
+			if msg.Token != token {
+				writeMessage(conn, &wireMessage{Type: msgError, Error: "unauthorized"})
+				return
+			}
+			reg = msg
+			// Loop around: assign the next range.
+		}
+	}
+}
+
+// readNextRegisterOrResult reads messages off a worker connection, folding
+// heartbeats into the global attempt counter, until it sees either a fresh
+// "register" (the worker exhausted its range with no match and wants
+// another) or a "found".
+func readNextRegisterOrResult(conn net.Conn, stopped *uint32) (*wireMessage, error) {
+	for {
+		if atomic.LoadUint32(stopped) == 1 {
+			return nil, fmt.Errorf("search stopped")
+		}
+		msg, err := readMessage(conn)
+		if err != nil {
+			return nil, err
+		}
+		switch msg.Type {
+		case msgHeartbeat:
+			atomic.AddUint64(&totalAttempts, msg.Attempts)
+		case msgRegister, msgFound:
+			return msg, nil
+		}
+	}
+}
+
+// runWorker implements the "worker" subcommand: register with a coordinator,
+// search the assigned nonce range deterministically, and report back.
+func runWorker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	coordAddr := fs.String("coordinator", "", "Coordinator address (host:port)")
+	token := fs.String("token", "", "Authentication token")
+	capability := fs.String("capability", fmt.Sprintf("cpu=%d", runtime.NumCPU()), "Capability string advertised to the coordinator, e.g. \"cpu=8\" or \"gpu=cuda\"")
+	localWorkers := fs.Int("workers", runtime.NumCPU(), "Number of local search goroutines")
+	fs.Parse(args)
+
+	if *coordAddr == "" {
+		fmt.Fprintln(os.Stderr, "Error: --coordinator is required")
+		os.Exit(1)
+	}
+
+	conn, err := net.Dial("tcp", *coordAddr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	if err := writeMessage(conn, &wireMessage{Type: msgRegister, Token: *token, Capability: *capability}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var writeMu sync.Mutex
+
+	// A single range-size (default 2^32) is far short of what a 12+ nibble
+	// prefix search needs, so keep requesting fresh ranges from the
+	// coordinator until one of them turns up a match.
+	for {
+		assign, err := readMessage(conn)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if assign.Type == msgError {
+			fmt.Fprintf(os.Stderr, "Error: coordinator rejected registration: %s\n", assign.Error)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Assigned range [%d, %d) for prefix=%q postfix=%q patterns=%v\n", assign.RangeStart, assign.RangeStart+assign.RangeSize, assign.Prefix, assign.Postfix, assign.Patterns)
+
+		matchers, err := buildMatchers(assign.Prefix, assign.Postfix, assign.Patterns)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var counter uint64 = assign.RangeStart
+		rangeEnd := assign.RangeStart + assign.RangeSize
+		var attempts uint64
+		var foundFlag uint32
+		var wg sync.WaitGroup
+
+		for i := 0; i < *localWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				searchRange(assign.RangeStart, &counter, rangeEnd, matchers, &attempts, &foundFlag, conn, &writeMu)
+			}()
+		}
+		wg.Wait()
+
+		if atomic.LoadUint32(&foundFlag) == 1 {
+			return
+		}
+
+		// Range exhausted with no match: ask for the next one.
+		writeMu.Lock()
+		err = writeMessage(conn, &wireMessage{Type: msgRegister, Token: *token, Capability: *capability})
+		writeMu.Unlock()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// searchRange deterministically searches a slice of a worker's assigned
+// nonce range: each attempt's randomness comes from sha256(rangeStart ||
+// counter || half) (see deterministicSeed) instead of crypto/rand.Read, so the search is reproducible and the
+// coordinator can safely hand out disjoint ranges. An address satisfying any
+// one of matchers ends the search for the whole swarm, the same way a single
+// --prefix/--postfix target would; matchers lets the coordinator's full
+// --pattern set (including "re:" regexes) apply in distributed mode too.
+func searchRange(rangeStart uint64, counter *uint64, rangeEnd uint64, matchers []Matcher, attempts *uint64, foundFlag *uint32, conn net.Conn, writeMu *sync.Mutex) {
+	nameHashFull := sha256.Sum256([]byte("lxmf.delivery"))
+	nameHash := nameHashFull[:10]
+
+	var localAttempts uint64
+	for {
+		if atomic.LoadUint32(foundFlag) == 1 {
+			return
+		}
+
+		n := atomic.AddUint64(counter, 1) - 1
+		if n >= rangeEnd {
+			return
+		}
+
+		var randBuf [64]byte
+		xPriv := deterministicSeed(rangeStart, n, 0)
+		edSeed := deterministicSeed(rangeStart, n, 1)
+		copy(randBuf[0:32], xPriv[:])
+		copy(randBuf[32:64], edSeed[:])
+
+		var identity Identity
+		copy(identity.X25519Private[:], randBuf[0:32])
+		copy(identity.Ed25519Seed[:], randBuf[32:64])
+
+		clampX25519(&identity.X25519Private)
+		curve25519.ScalarBaseMult(&identity.X25519Public, &identity.X25519Private)
+		generateEd25519Public(&identity)
+
+		var publicKey [64]byte
+		copy(publicKey[0:32], identity.X25519Public[:])
+		copy(publicKey[32:64], identity.Ed25519Public[:])
+		identityHashFull := sha256.Sum256(publicKey[:])
+		copy(identity.Hash[:], identityHashFull[:16])
+
+		var addrHashMaterial [26]byte
+		copy(addrHashMaterial[0:10], nameHash)
+		copy(addrHashMaterial[10:26], identity.Hash[:])
+		addrHashFull := sha256.Sum256(addrHashMaterial[:])
+		copy(identity.Address[:], addrHashFull[:16])
+
+		localAttempts++
+		if localAttempts%4096 == 0 {
+			atomic.AddUint64(attempts, 4096)
+			writeMu.Lock()
+			writeMessage(conn, &wireMessage{Type: msgHeartbeat, Attempts: 4096})
+			writeMu.Unlock()
+		}
+
+		addrHex := hex.EncodeToString(identity.Address[:])
+		if matchesAny(matchers, addrHex) && atomic.CompareAndSwapUint32(foundFlag, 0, 1) {
+			var privKey [64]byte
+			copy(privKey[0:32], identity.X25519Private[:])
+			copy(privKey[32:64], identity.Ed25519Seed[:])
+			writeMu.Lock()
+			writeMessage(conn, &wireMessage{Type: msgFound, Identity: privKey[:], Address: addrHex})
+			writeMu.Unlock()
+			return
+		}
+	}
+}
+
+// matchesAny reports whether addrHex satisfies any of matchers.
+func matchesAny(matchers []Matcher, addrHex string) bool {
+	for _, m := range matchers {
+		if m.Match(addrHex) {
+			return true
+		}
+	}
+	return false
+}
+
+// deterministicSeed derives 32 bytes of worker randomness from the nonce
+// range and attempt counter so a distributed search is reproducible: the
+// coordinator can re-assign a range and get the exact same attempts back.
+func deterministicSeed(rangeStart, counter uint64, half byte) [32]byte {
+	var material [17]byte
+	binary.BigEndian.PutUint64(material[0:8], rangeStart)
+	binary.BigEndian.PutUint64(material[8:16], counter)
+	material[16] = half
+	return sha256.Sum256(material[:])
+}
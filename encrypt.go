@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Encrypted identity container format:
+//
+//	magic[4] "LXV1" | logN[1] | r[1] | p[1] | salt[16] | nonce[24] | ciphertext+tag
+//
+// The key is derived from the passphrase with scrypt and used to seal the
+// 64-byte identity blob with XChaCha20-Poly1305.
+var containerMagic = [4]byte{'L', 'X', 'V', '1'}
+
+const (
+	scryptSaltSize = 16
+	scryptLogN     = 17 // N = 2^17
+	scryptR        = 8
+	scryptP        = 1
+	scryptKeySize  = chacha20poly1305.KeySize
+)
+
+func encryptContainer(plaintext, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, scryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+
+	key, err := scrypt.Key(passphrase, salt, 1<<scryptLogN, scryptR, scryptP, scryptKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: derive key: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("encrypt: %w", err)
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+
+	buf := new(bytes.Buffer)
+	buf.Write(containerMagic[:])
+	buf.WriteByte(scryptLogN)
+	buf.WriteByte(scryptR)
+	buf.WriteByte(scryptP)
+	buf.Write(salt)
+	buf.Write(nonce)
+	buf.Write(ciphertext)
+	return buf.Bytes(), nil
+}
+
+func decryptContainer(container, passphrase []byte) ([]byte, error) {
+	const headerSize = 4 + 3 + scryptSaltSize
+	if len(container) < headerSize {
+		return nil, fmt.Errorf("decrypt: file too small to be an encrypted identity")
+	}
+	if !bytes.Equal(container[0:4], containerMagic[:]) {
+		return nil, fmt.Errorf("decrypt: not an encrypted identity file (bad magic)")
+	}
+
+	logN, r, p := int(container[4]), int(container[5]), int(container[6])
+	salt := container[7 : 7+scryptSaltSize]
+	rest := container[7+scryptSaltSize:]
+
+	nonceSize := chacha20poly1305.NonceSizeX
+	if len(rest) < nonceSize {
+		return nil, fmt.Errorf("decrypt: file too small to be an encrypted identity")
+	}
+	nonce := rest[:nonceSize]
+	ciphertext := rest[nonceSize:]
+
+	key, err := scrypt.Key(passphrase, salt, 1<<uint(logN), r, p, scryptKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: derive key: %w", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: wrong passphrase or corrupt file")
+	}
+	return plaintext, nil
+}
+
+// resolvePassphrase returns the passphrase from --passphrase or
+// --passphrase-file if set, otherwise prompts interactively without echoing
+// input. When confirm is true the interactive prompt is entered twice and
+// must match, to guard against a typo locking the identity away.
+func resolvePassphrase(confirm bool) ([]byte, error) {
+	if passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	if passphraseFile != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase file: %w", err)
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+
+	fmt.Print("Passphrase: ")
+	pass, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+
+	if confirm {
+		fmt.Print("Confirm passphrase: ")
+		again, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase: %w", err)
+		}
+		if !bytes.Equal(pass, again) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	return pass, nil
+}
@@ -4,8 +4,10 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding"
 	"encoding/base32"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"flag"
 	"fmt"
@@ -21,18 +23,27 @@ import (
 
 // CLI flags
 var (
-	prefix  string
-	postfix string
-	workers int
-	outPath string
-	dryRun  bool
+	prefix         string
+	postfix        string
+	workers        int
+	outPath        string
+	dryRun         bool
+	showMnemonic   bool
+	restorePhrase  string
+	encrypt        bool
+	passphrase     string
+	passphraseFile string
+	decryptPath    string
+	patterns       patternList
 )
 
-// Parsed prefix/postfix patterns for fast matching
-var (
-	prefixNibbles  []byte
-	postfixNibbles []byte
-)
+// matchers holds the compiled vanity targets for the current run, built once
+// by compilePatterns before the worker pool starts.
+var matchers []Matcher
+
+// matcherFound tracks, per entry in matchers, whether a result has already
+// been produced for it so the worker pool can keep searching for the rest.
+var matcherFound []uint32
 
 // Global counters
 var (
@@ -56,11 +67,41 @@ func init() {
 	flag.IntVar(&workers, "workers", runtime.NumCPU(), "Number of parallel workers")
 	flag.StringVar(&outPath, "out", "identity", "Output path for identity file")
 	flag.BoolVar(&dryRun, "dry-run", false, "Only measure speed, don't save")
+	flag.BoolVar(&showMnemonic, "mnemonic", false, "Also print a 48-word BIP-39 mnemonic backup phrase in the .txt file")
+	flag.StringVar(&restorePhrase, "restore", "", "Restore an identity from a BIP-39 mnemonic phrase instead of searching")
+	flag.BoolVar(&encrypt, "encrypt", false, "Encrypt the saved identity file with a passphrase-derived key")
+	flag.StringVar(&passphrase, "passphrase", "", "Passphrase for --encrypt/--decrypt (prompted for if omitted)")
+	flag.StringVar(&passphraseFile, "passphrase-file", "", "Read the passphrase for --encrypt/--decrypt from a file")
+	flag.StringVar(&decryptPath, "decrypt", "", "Decrypt an identity file written with --encrypt and re-save it in plain form")
+	flag.Var(&patterns, "pattern", "Additional vanity target: \"prefix:postfix\" or \"re:<regex>\" against the hex address; repeatable")
 }
 
 func main() {
+	// "coordinator" and "worker" are distributed-search subcommands with
+	// their own flag sets; anything else runs the classic single-box search.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "coordinator":
+			runCoordinator(os.Args[2:])
+			return
+		case "worker":
+			runWorker(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Parse()
 
+	if restorePhrase != "" {
+		runRestore()
+		return
+	}
+
+	if decryptPath != "" {
+		runDecrypt()
+		return
+	}
+
 	// Validate and normalize inputs
 	if err := validateInputs(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -70,20 +111,17 @@ func main() {
 	prefix = strings.ToLower(prefix)
 	postfix = strings.ToLower(postfix)
 
-	// Parse patterns for fast comparison
-	if prefix != "" {
-		prefixNibbles = hexToNibbles(prefix)
-	}
-	if postfix != "" {
-		postfixNibbles = hexToNibbles(postfix)
+	var err error
+	matchers, err = compilePatterns()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+	matcherFound = make([]uint32, len(matchers))
 
 	fmt.Printf("Searching for LXMF vanity address...\n")
-	if prefix != "" {
-		fmt.Printf("  Prefix:  %s\n", prefix)
-	}
-	if postfix != "" {
-		fmt.Printf("  Postfix: %s\n", postfix)
+	for _, m := range matchers {
+		fmt.Printf("  Target:  %s (~%s attempts expected)\n", m.Label(), estimateAttempts(m))
 	}
 	fmt.Printf("  Workers: %d\n", workers)
 	if dryRun {
@@ -94,7 +132,7 @@ func main() {
 	// Start worker pool and monitoring
 	go monitorProgress()
 
-	resultChan := make(chan *Identity, 1)
+	resultChan := make(chan matchResult, len(matchers))
 	var wg sync.WaitGroup
 
 	for i := 0; i < workers; i++ {
@@ -102,24 +140,35 @@ func main() {
 		go worker(&wg, resultChan)
 	}
 
-	// Wait for result and stop workers
-	identity := <-resultChan
-	atomic.StoreUint32(&found, 1)
-
-	wg.Wait()
+	// Collect one result per matcher, saving each as it arrives, until every
+	// pattern has been satisfied.
+	results := make([]*Identity, len(matchers))
+	remaining := len(matchers)
+	for remaining > 0 {
+		r := <-resultChan
+		if results[r.idx] != nil {
+			continue
+		}
+		results[r.idx] = r.identity
+		remaining--
 
-	// Display result
-	addrHex := hex.EncodeToString(identity.Address[:])
-	fmt.Printf("\n✓ Found matching address: %s\n", addrHex)
-	fmt.Printf("  Total attempts: %d\n", atomic.LoadUint64(&totalAttempts))
+		addrHex := hex.EncodeToString(r.identity.Address[:])
+		fmt.Printf("\n✓ Found match for %q: %s\n", matchers[r.idx].Label(), addrHex)
 
-	if !dryRun {
-		if err := saveIdentity(identity, outPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error saving identity: %v\n", err)
-			os.Exit(1)
+		if !dryRun {
+			path := fmt.Sprintf("%s-%s", outPath, addrHex)
+			if err := saveIdentity(r.identity, path); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving identity: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("  Saved to: %s\n", path)
 		}
-		fmt.Printf("  Saved to: %s\n", outPath)
 	}
+
+	atomic.StoreUint32(&found, 1)
+	wg.Wait()
+
+	fmt.Printf("\nTotal attempts: %d\n", atomic.LoadUint64(&totalAttempts))
 }
 
 func validateInputs() error {
@@ -143,10 +192,6 @@ func validateInputs() error {
 		}
 	}
 
-	if prefix == "" && postfix == "" {
-		return fmt.Errorf("at least one of --prefix or --postfix must be specified")
-	}
-
 	if workers < 1 {
 		return fmt.Errorf("workers must be at least 1")
 	}
@@ -163,65 +208,107 @@ func isHex(s string) bool {
 	return true
 }
 
-func worker(wg *sync.WaitGroup, resultChan chan<- *Identity) {
+// matchResult pairs a found identity with the index into matchers it
+// satisfied, so the collector loop in main knows which pattern is done.
+type matchResult struct {
+	identity *Identity
+	idx      int
+}
+
+func worker(wg *sync.WaitGroup, resultChan chan<- matchResult) {
 	defer wg.Done()
 
-	// Pre-allocate buffers for performance
-	var randBuf [64]byte
+	// The X25519 half of the identity is generated once and held fixed for
+	// this worker's lifetime; only the Ed25519 half is walked per attempt
+	// (see deriveEd25519Seed), which trades half the random-key-generation
+	// cost for a slightly smaller search space per worker.
+	var x25519Private [32]byte
+	if _, err := rand.Read(x25519Private[:]); err != nil {
+		return
+	}
+	clampX25519(&x25519Private)
+	var x25519Public [32]byte
+	curve25519.ScalarBaseMult(&x25519Public, &x25519Private)
+
+	var edSeed [32]byte
+	if _, err := rand.Read(edSeed[:]); err != nil {
+		return
+	}
+	var counter uint64
 
-	// Pre-compute name hash for LXMF (constant across all iterations)
+	// Pre-compute the SHA-256 midstate over the constant name-hash prefix so
+	// each attempt only has to absorb the 16-byte identity hash tail.
 	nameHashFull := sha256.Sum256([]byte("lxmf.delivery"))
-	nameHash := nameHashFull[:10]
+	nameHasher := sha256.New()
+	nameHasher.Write(nameHashFull[:10])
+	midstate, err := nameHasher.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return
+	}
+
+	var publicKey [64]byte
+	copy(publicKey[0:32], x25519Public[:])
 
 	for {
-		// Check if another worker found a match
+		// Check if the search is over (all patterns found, or cancelled)
 		if atomic.LoadUint32(&found) == 1 {
 			return
 		}
 
-		// Generate random bytes
-		if _, err := rand.Read(randBuf[:]); err != nil {
-			continue
-		}
+		var counterBytes [8]byte
+		binary.BigEndian.PutUint64(counterBytes[:], counter)
+		counter++
 
-		// Create and derive identity
 		var identity Identity
-		copy(identity.X25519Private[:], randBuf[0:32])
-		copy(identity.Ed25519Seed[:], randBuf[32:64])
-
-		// Generate key pairs
-		clampX25519(&identity.X25519Private)
-		curve25519.ScalarBaseMult(&identity.X25519Public, &identity.X25519Private)
+		identity.X25519Private = x25519Private
+		identity.X25519Public = x25519Public
+		identity.Ed25519Seed = deriveEd25519Seed(edSeed, counterBytes)
 		generateEd25519Public(&identity)
 
-		// Build public key and compute hashes
-		var publicKey [64]byte
-		copy(publicKey[0:32], identity.X25519Public[:])
 		copy(publicKey[32:64], identity.Ed25519Public[:])
-
 		identityHashFull := sha256.Sum256(publicKey[:])
 		copy(identity.Hash[:], identityHashFull[:16])
 
-		// Compute LXMF destination address
-		var addrHashMaterial [26]byte
-		copy(addrHashMaterial[0:10], nameHash)
-		copy(addrHashMaterial[10:26], identity.Hash[:])
-
-		addrHashFull := sha256.Sum256(addrHashMaterial[:])
-		copy(identity.Address[:], addrHashFull[:16])
+		// Compute LXMF destination address by cloning the name-hash midstate
+		// and absorbing only the 16-byte identity hash.
+		addrHasher := sha256.New()
+		if err := addrHasher.(encoding.BinaryUnmarshaler).UnmarshalBinary(midstate); err != nil {
+			return
+		}
+		addrHasher.Write(identity.Hash[:])
+		copy(identity.Address[:], addrHasher.Sum(nil)[:16])
 
-		// Check if address matches pattern
 		atomic.AddUint64(&totalAttempts, 1)
-		if matchesPattern(identity.Address[:]) {
-			select {
-			case resultChan <- &identity:
-			default:
+
+		// Check the address against every pattern still being searched for
+		addrHex := hex.EncodeToString(identity.Address[:])
+		for i, m := range matchers {
+			if atomic.LoadUint32(&matcherFound[i]) == 1 {
+				continue
+			}
+			if !m.Match(addrHex) {
+				continue
+			}
+			if atomic.CompareAndSwapUint32(&matcherFound[i], 0, 1) {
+				result := identity
+				select {
+				case resultChan <- matchResult{identity: &result, idx: i}:
+				default:
+				}
 			}
-			return
 		}
 	}
 }
 
+// deriveEd25519Seed walks a per-worker counter to produce a fresh Ed25519
+// seed for each attempt without drawing from crypto/rand every time.
+func deriveEd25519Seed(workerSeed [32]byte, counter [8]byte) [32]byte {
+	var material [40]byte
+	copy(material[0:32], workerSeed[:])
+	copy(material[32:40], counter[:])
+	return sha256.Sum256(material[:])
+}
+
 func generateEd25519Public(identity *Identity) {
 	// Generate Ed25519 public key from seed (crypto/ed25519 handles SHA-512 internally)
 	publicKey := ed25519.NewKeyFromSeed(identity.Ed25519Seed[:]).Public().(ed25519.PublicKey)
@@ -234,59 +321,103 @@ func clampX25519(privateKey *[32]byte) {
 	privateKey[31] |= 64  // Set second-highest bit
 }
 
-// hexToNibbles converts hex string to nibbles for fast comparison
-func hexToNibbles(s string) []byte {
-	nibbles := make([]byte, len(s))
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c >= '0' && c <= '9' {
-			nibbles[i] = c - '0'
-		} else if c >= 'a' && c <= 'f' {
-			nibbles[i] = c - 'a' + 10
-		}
+// identityFromPrivateBlob reconstructs an Identity from its 64-byte private
+// blob (X25519 private key followed by Ed25519 seed), deriving the public
+// keys, identity hash and LXMF address the same way the worker loop does.
+func identityFromPrivateBlob(data []byte) (*Identity, error) {
+	if len(data) != 64 {
+		return nil, fmt.Errorf("identity: expected 64 bytes of private key material, got %d", len(data))
 	}
-	return nibbles
+
+	identity := &Identity{}
+	copy(identity.X25519Private[:], data[0:32])
+	copy(identity.Ed25519Seed[:], data[32:64])
+
+	clampX25519(&identity.X25519Private)
+	curve25519.ScalarBaseMult(&identity.X25519Public, &identity.X25519Private)
+	generateEd25519Public(identity)
+
+	var publicKey [64]byte
+	copy(publicKey[0:32], identity.X25519Public[:])
+	copy(publicKey[32:64], identity.Ed25519Public[:])
+	identityHashFull := sha256.Sum256(publicKey[:])
+	copy(identity.Hash[:], identityHashFull[:16])
+
+	nameHashFull := sha256.Sum256([]byte("lxmf.delivery"))
+	var addrHashMaterial [26]byte
+	copy(addrHashMaterial[0:10], nameHashFull[:10])
+	copy(addrHashMaterial[10:26], identity.Hash[:])
+	addrHashFull := sha256.Sum256(addrHashMaterial[:])
+	copy(identity.Address[:], addrHashFull[:16])
+
+	return identity, nil
 }
 
-// matchesPattern checks if address matches prefix/postfix patterns using nibble comparison
-func matchesPattern(addr []byte) bool {
-	// Check prefix
-	if len(prefixNibbles) > 0 {
-		for i := 0; i < len(prefixNibbles); i++ {
-			byteIdx := i / 2
-			nibble := byte(0)
-			if i%2 == 0 {
-				nibble = (addr[byteIdx] >> 4) & 0x0F
-			} else {
-				nibble = addr[byteIdx] & 0x0F
-			}
-			if nibble != prefixNibbles[i] {
-				return false
-			}
-		}
+// runRestore implements the --restore mode: decode the mnemonic back into the
+// 64-byte private blob, rebuild the identity, and save it like a normal run.
+func runRestore() {
+	data, err := mnemonicToBytes(restorePhrase)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// Check postfix
-	if len(postfixNibbles) > 0 {
-		addrLen := len(addr) * 2
-		startNibble := addrLen - len(postfixNibbles)
+	identity, err := identityFromPrivateBlob(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
-		for i := 0; i < len(postfixNibbles); i++ {
-			nibbleIdx := startNibble + i
-			byteIdx := nibbleIdx / 2
-			nibble := byte(0)
-			if nibbleIdx%2 == 0 {
-				nibble = (addr[byteIdx] >> 4) & 0x0F
-			} else {
-				nibble = addr[byteIdx] & 0x0F
-			}
-			if nibble != postfixNibbles[i] {
-				return false
-			}
+	addrHex := hex.EncodeToString(identity.Address[:])
+	fmt.Printf("Restored identity: %s\n", addrHex)
+
+	if !dryRun {
+		if err := saveIdentity(identity, outPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving identity: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Printf("  Saved to: %s\n", outPath)
 	}
+}
 
-	return true
+// runDecrypt implements the --decrypt mode: read an --encrypt container,
+// prompt for the passphrase, and re-save the identity in plain form (or
+// re-encrypted, if --encrypt is also given) via the normal saveIdentity path.
+func runDecrypt() {
+	container, err := os.ReadFile(decryptPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	pass, err := resolvePassphrase(false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := decryptContainer(container, pass)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	identity, err := identityFromPrivateBlob(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	addrHex := hex.EncodeToString(identity.Address[:])
+	fmt.Printf("Decrypted identity: %s\n", addrHex)
+
+	if !dryRun {
+		if err := saveIdentity(identity, outPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving identity: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("  Saved to: %s\n", outPath)
+	}
 }
 
 func monitorProgress() {
@@ -327,22 +458,49 @@ func formatNumber(n uint64) string {
 }
 
 func saveIdentity(identity *Identity, path string) error {
-	// Save binary identity file (X25519 private + Ed25519 seed)
-	file, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+	// Combined identity bytes (X25519 private + Ed25519 seed)
+	var privKey [64]byte
+	copy(privKey[0:32], identity.X25519Private[:])
+	copy(privKey[32:64], identity.Ed25519Seed[:])
 
-	if _, err := file.Write(identity.X25519Private[:]); err != nil {
-		return err
-	}
-	if _, err := file.Write(identity.Ed25519Seed[:]); err != nil {
-		return err
+	if encrypt {
+		pass, err := resolvePassphrase(true)
+		if err != nil {
+			return err
+		}
+		container, err := encryptContainer(privKey[:], pass)
+		if err != nil {
+			return err
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.Write(container); err != nil {
+			return err
+		}
+	} else {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.Write(privKey[:]); err != nil {
+			return err
+		}
 	}
 
-	// Create human-readable info file
-	infoPath := path + ".txt"
+	return writeInfoFile(identity, path+".txt", privKey)
+}
+
+// writeInfoFile writes the human-readable .txt companion file describing an
+// identity: its address/hash and public keys always, and (unless --encrypt
+// is set) its private key in every supported export encoding plus, with
+// --mnemonic, a BIP-39 backup phrase. With --encrypt, the private key only
+// lives in the encrypted container; the .txt must not leak a plaintext copy
+// next to it, so all private-key material is omitted.
+func writeInfoFile(identity *Identity, infoPath string, privKey [64]byte) error {
 	infoFile, err := os.Create(infoPath)
 	if err != nil {
 		return err
@@ -356,12 +514,14 @@ func saveIdentity(identity *Identity, path string) error {
 
 	fmt.Fprintf(infoFile, "Public Key (X25519 + Ed25519):\n")
 	fmt.Fprintf(infoFile, "  X25519 Public:  %s\n", hex.EncodeToString(identity.X25519Public[:]))
-	fmt.Fprintf(infoFile, "  Ed25519 Public: %s\n\n", hex.EncodeToString(identity.Ed25519Public[:]))
+	fmt.Fprintf(infoFile, "  Ed25519 Public: %s\n", hex.EncodeToString(identity.Ed25519Public[:]))
 
-	// Prepare combined identity bytes (X25519 private + Ed25519 seed)
-	var privKey [64]byte
-	copy(privKey[0:32], identity.X25519Private[:])
-	copy(privKey[32:64], identity.Ed25519Seed[:])
+	if encrypt {
+		fmt.Fprintln(infoFile)
+		fmt.Fprintln(infoFile, "Private key material is sealed in the encrypted identity file saved alongside this one, not here.")
+		return nil
+	}
+	fmt.Fprintln(infoFile)
 
 	// Encoders: standard Base64 (padded) and Base32 (padded, uppercase)
 	b64 := base64.StdEncoding.EncodeToString(privKey[:])
@@ -377,5 +537,14 @@ func saveIdentity(identity *Identity, path string) error {
 	fmt.Fprintf(infoFile, "Base64 (MeshChat import string):\n%s\n", b64)
 	fmt.Fprintf(infoFile, "Base32 (Sideband import string):\n%s\n", b32)
 
+	if showMnemonic {
+		phrase, err := bytesToMnemonic(privKey[:])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(infoFile)
+		fmt.Fprintf(infoFile, "Mnemonic (BIP-39, 48 words, restore with --restore):\n%s\n", phrase)
+	}
+
 	return nil
 }
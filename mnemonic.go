@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// bip39Index maps each word in bip39WordList to its index, built once so
+// mnemonicToBytes can look up words in O(1) instead of scanning the list.
+var bip39Index = func() map[string]int {
+	m := make(map[string]int, len(bip39WordList))
+	for i, w := range bip39WordList {
+		m[w] = i
+	}
+	return m
+}()
+
+// bytesToMnemonic encodes data as a BIP-39 mnemonic phrase. len(data) must be
+// a non-zero multiple of 4 bytes: the checksum is the first len(data)*8/32
+// bits of SHA-256(data), appended to the entropy before splitting the whole
+// bit stream into 11-bit word indices.
+func bytesToMnemonic(data []byte) (string, error) {
+	if len(data) == 0 || len(data)%4 != 0 {
+		return "", fmt.Errorf("mnemonic: entropy length must be a non-zero multiple of 4 bytes, got %d", len(data))
+	}
+
+	checksumBits := len(data) * 8 / 32
+	hash := sha256.Sum256(data)
+
+	bits := make([]byte, 0, len(data)*8+checksumBits)
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			bits = append(bits, (b>>uint(i))&1)
+		}
+	}
+	for i := 0; i < checksumBits; i++ {
+		byteIdx, bitIdx := i/8, 7-(i%8)
+		bits = append(bits, (hash[byteIdx]>>uint(bitIdx))&1)
+	}
+
+	words := make([]string, len(bits)/11)
+	for i := range words {
+		idx := 0
+		for j := 0; j < 11; j++ {
+			idx = (idx << 1) | int(bits[i*11+j])
+		}
+		words[i] = bip39WordList[idx]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// mnemonicToBytes reverses bytesToMnemonic, verifying the embedded checksum
+// before returning the recovered entropy.
+func mnemonicToBytes(phrase string) ([]byte, error) {
+	fields := strings.Fields(strings.ToLower(phrase))
+	totalBits := len(fields) * 11
+	if len(fields) == 0 || totalBits%33 != 0 {
+		return nil, fmt.Errorf("mnemonic: %d words is not a valid BIP-39 phrase length", len(fields))
+	}
+
+	checksumBits := totalBits / 33
+	entropyBits := totalBits - checksumBits
+
+	bits := make([]byte, 0, totalBits)
+	for _, w := range fields {
+		idx, ok := bip39Index[w]
+		if !ok {
+			return nil, fmt.Errorf("mnemonic: unknown word %q", w)
+		}
+		for i := 10; i >= 0; i-- {
+			bits = append(bits, byte((idx>>uint(i))&1))
+		}
+	}
+
+	data := make([]byte, entropyBits/8)
+	for i := range data {
+		var b byte
+		for j := 0; j < 8; j++ {
+			b = (b << 1) | bits[i*8+j]
+		}
+		data[i] = b
+	}
+
+	hash := sha256.Sum256(data)
+	for i := 0; i < checksumBits; i++ {
+		byteIdx, bitIdx := i/8, 7-(i%8)
+		want := (hash[byteIdx] >> uint(bitIdx)) & 1
+		if want != bits[entropyBits+i] {
+			return nil, fmt.Errorf("mnemonic: checksum mismatch, phrase may be mistyped")
+		}
+	}
+	return data, nil
+}
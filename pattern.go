@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// patternList is a repeatable -pattern flag value; each occurrence appends a
+// raw pattern string, which compilePatterns later turns into a Matcher.
+type patternList []string
+
+func (p *patternList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *patternList) Set(v string) error {
+	*p = append(*p, v)
+	return nil
+}
+
+// Matcher tests a hex-encoded LXMF address against a vanity target.
+type Matcher interface {
+	Match(addrHex string) bool
+	Label() string
+}
+
+// affixMatcher matches addresses by hex prefix and/or postfix, the same
+// comparison the original --prefix/--postfix flags performed.
+type affixMatcher struct {
+	prefix  string
+	postfix string
+}
+
+func (m *affixMatcher) Match(addrHex string) bool {
+	return strings.HasPrefix(addrHex, m.prefix) && strings.HasSuffix(addrHex, m.postfix)
+}
+
+func (m *affixMatcher) Label() string {
+	switch {
+	case m.prefix != "" && m.postfix != "":
+		return fmt.Sprintf("%s...%s", m.prefix, m.postfix)
+	case m.prefix != "":
+		return m.prefix + "..."
+	default:
+		return "..." + m.postfix
+	}
+}
+
+// regexMatcher matches addresses against an arbitrary regular expression.
+type regexMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m *regexMatcher) Match(addrHex string) bool {
+	return m.re.MatchString(addrHex)
+}
+
+func (m *regexMatcher) Label() string {
+	return m.re.String()
+}
+
+// compilePatterns builds the list of matchers to search for from the
+// top-level --prefix/--postfix/--pattern flags; see buildMatchers for the
+// underlying logic, which is also used by the coordinator/worker subcommands
+// to rebuild the same matcher set from the wire-carried pattern strings.
+func compilePatterns() ([]Matcher, error) {
+	return buildMatchers(prefix, postfix, patterns)
+}
+
+// buildMatchers builds the list of matchers to search for: the legacy
+// prefix/postfix pair (if given) plus one matcher per pattern string. A
+// pattern value is either "re:<regex>" for a full regular expression
+// evaluated against the hex address, or "prefix:postfix" (either side may be
+// empty) for another affix target.
+func buildMatchers(prefix, postfix string, patterns []string) ([]Matcher, error) {
+	var matchers []Matcher
+
+	if prefix != "" || postfix != "" {
+		matchers = append(matchers, &affixMatcher{prefix: prefix, postfix: postfix})
+	}
+
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "re:") {
+			re, err := regexp.Compile(strings.TrimPrefix(p, "re:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid --pattern regex %q: %w", p, err)
+			}
+			matchers = append(matchers, &regexMatcher{re: re})
+			continue
+		}
+
+		parts := strings.SplitN(p, ":", 2)
+		pfx := strings.ToLower(parts[0])
+		var sfx string
+		if len(parts) == 2 {
+			sfx = strings.ToLower(parts[1])
+		}
+		if !isHex(pfx) || !isHex(sfx) {
+			return nil, fmt.Errorf("invalid --pattern %q: prefix/postfix must be hex, or prefix with \"re:\" for a regex", p)
+		}
+		matchers = append(matchers, &affixMatcher{prefix: pfx, postfix: sfx})
+	}
+
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("at least one of --prefix, --postfix, or --pattern must be specified")
+	}
+
+	return matchers, nil
+}
+
+// estimateAttempts returns a human-readable estimate of the expected number
+// of attempts to find a match, or "unknown (regex pattern)" for patterns whose
+// odds can't be derived from nibble counts (i.e. arbitrary regexes).
+func estimateAttempts(m Matcher) string {
+	am, ok := m.(*affixMatcher)
+	if !ok {
+		return "unknown (regex pattern)"
+	}
+	nibbles := len(am.prefix) + len(am.postfix)
+	if nibbles >= 16 {
+		// 16^nibbles no longer fits in a uint64.
+		return fmt.Sprintf("2^%d", 4*nibbles)
+	}
+	return formatNumber(uint64(math.Pow(16, float64(nibbles))))
+}